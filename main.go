@@ -16,6 +16,10 @@ import (
 // Flag pointers
 var dfp = flag.Bool("debug", false, "Log debug statements in aliaspanel.log")
 var mfp = flag.String("margin", "2", "Margin size (default 2)")
+var pfp = flag.String("preview", "", "Preview command template run against the selected alias (e.g. 'type {name}'); enables the preview pane when set")
+var efp = flag.Int("execute-fd", 3, "File descriptor to write the selected alias's command to on Enter")
+var zfp = flag.Bool("print0", false, "NUL-terminate (instead of newline-terminate) the command written to --execute-fd")
+var sfp = flag.String("shell", "", "Print a shell wrapper function for the named shell (bash, zsh, or fish) and exit; source its output to pick up the `alias-panel` shell function")
 
 func init() {
 	flag.Parse()
@@ -38,10 +42,20 @@ func init() {
 }
 
 func main() {
+	if *sfp != "" {
+		snippet, err := shellSnippet(*sfp)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		os.Stdout.WriteString(snippet)
+		return
+	}
+
 	sm := parser.ParseAll()
 	log.Debugf("Sections: \n%v", spew.Sdump(sm))
 	if m, err := strconv.Atoi(*mfp); err == nil {
-		panel.DrawScreen(sm, m)
+		panel.DrawScreen(sm, m, *pfp, *efp, *zfp)
 	} else {
 		panic(err)
 	}
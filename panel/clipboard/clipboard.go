@@ -0,0 +1,31 @@
+// Package clipboard copies text to the system clipboard.
+package clipboard
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Copy writes the OSC 52 clipboard escape sequence for text to w. This is
+// the default (and only) method: it sets the host terminal's clipboard
+// without shelling out to an external binary, so it also works over SSH.
+func Copy(w io.Writer, text string) error {
+	enc := base64.StdEncoding.EncodeToString([]byte(text))
+	_, err := fmt.Fprintf(w, "\x1b]52;c;%s\x07", enc)
+	return err
+}
+
+// CopyTTY writes the OSC 52 sequence for text directly to /dev/tty, the
+// terminal tcell itself drives. Writing to os.Stdout instead would miss
+// whenever stdout isn't the controlling terminal (e.g. redirected, or
+// piped through the alias-panel shell wrapper's fd juggling).
+func CopyTTY(text string) error {
+	f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return Copy(f, text)
+}
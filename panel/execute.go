@@ -0,0 +1,30 @@
+package panel
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/BradyBolton/alias-panel/parser"
+)
+
+// writeExecute writes a's Cmd to the file descriptor fd (inherited from a
+// shell wrapper, e.g. via `alias-panel --shell bash`) so the invocation can
+// be eval'd back in the parent shell. The write is newline-terminated, or
+// NUL-terminated if print0 is set.
+func writeExecute(fd int, print0 bool, a parser.Alias) {
+	f := os.NewFile(uintptr(fd), "execute-fd")
+	if f == nil {
+		log.Errorf("writeExecute: fd %d is not open", fd)
+		return
+	}
+	defer f.Close()
+
+	term := "\n"
+	if print0 {
+		term = "\x00"
+	}
+	if _, err := f.WriteString(a.Cmd + term); err != nil {
+		log.Error(err)
+	}
+}
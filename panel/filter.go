@@ -0,0 +1,262 @@
+package panel
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/BradyBolton/alias-panel/parser"
+)
+
+// Fuzzy scoring constants, loosely modeled on fzf's default scoring scheme.
+const (
+	matchScore       = 16
+	boundaryBonus    = 8
+	consecutiveBonus = 4
+	gapPenalty       = 1
+)
+
+// filterState tracks the query typed into the filter prompt at the bottom of
+// the screen.
+type filterState struct {
+	query []rune
+}
+
+// String returns the current query as a string.
+func (fs *filterState) String() string {
+	return string(fs.query)
+}
+
+// appendRune appends r to the query.
+func (fs *filterState) appendRune(r rune) {
+	fs.query = append(fs.query, r)
+}
+
+// backspace removes the last rune of the query (if any).
+func (fs *filterState) backspace() {
+	if len(fs.query) > 0 {
+		fs.query = fs.query[:len(fs.query)-1]
+	}
+}
+
+// clear empties the query.
+func (fs *filterState) clear() {
+	fs.query = fs.query[:0]
+}
+
+// matchResult holds the outcome of scoring a candidate string against a
+// query.
+type matchResult struct {
+	score int
+	span  int
+}
+
+// isWordBoundary reports whether rune i of s begins a new "word", i.e. it
+// follows a separator (_, -, /, space) or is the start of a camelCase hump.
+func isWordBoundary(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := s[i-1]
+	switch prev {
+	case '_', '-', '/', ' ':
+		return true
+	}
+	cur := s[i]
+	return unicode.IsUpper(cur) && unicode.IsLower(prev)
+}
+
+// matchNegInf marks a dpState as unreachable: no subsequence of candidate up
+// to this position matches the query prefix in question.
+const matchNegInf = -1 << 30
+
+// dpState is the best score (and the candidate index the match started at)
+// of matching some prefix of query, ending with a match at a given
+// candidate position.
+type dpState struct {
+	score int
+	start int
+}
+
+// better reports whether a scores higher than b, breaking ties in favor of
+// the later start (i.e. the shorter span), matching rankedAliasNames' own
+// score-then-span tie-break.
+func (a dpState) better(b dpState) bool {
+	if a.score != b.score {
+		return a.score > b.score
+	}
+	return a.start > b.start
+}
+
+// fuzzyMatch reports whether every rune of query appears, in order and
+// case-insensitively, within candidate. When it does, it also returns a
+// matchResult scoring the best such alignment: bonuses for matches at word
+// boundaries and for consecutive runs, and a small penalty for each gap
+// between matched runes. This is a max-score dynamic program over
+// (query position, candidate position) pairs, not a first-match greedy
+// scan, so it finds the best-scoring subsequence even when candidate has
+// repeated letters. An empty query matches everything with a zero score.
+func fuzzyMatch(query, candidate string) (matchResult, bool) {
+	if query == "" {
+		return matchResult{}, true
+	}
+
+	q := []rune(strings.ToLower(query))
+	lc := []rune(strings.ToLower(candidate))
+	oc := []rune(candidate)
+
+	// prev/cur hold, per candidate position, the best dpState matching
+	// query[:i+1] and ending exactly at that position (or matchNegInf if
+	// unreachable).
+	prev := make([]dpState, len(lc))
+	cur := make([]dpState, len(lc))
+	for j := range prev {
+		prev[j] = dpState{score: matchNegInf}
+	}
+
+	// prefixBest[k] is the best dpState over prev[0:k+1], used to score a
+	// match of query[i] that follows a gap rather than immediately
+	// following the match of query[i-1].
+	prefixBest := make([]dpState, len(lc))
+
+	for i := 0; i < len(q); i++ {
+		if i > 0 {
+			running := dpState{score: matchNegInf}
+			for k, st := range prev {
+				if st.score > matchNegInf && st.better(running) {
+					running = st
+				}
+				prefixBest[k] = running
+			}
+		}
+
+		for j := range cur {
+			cur[j] = dpState{score: matchNegInf}
+		}
+
+		for j, c := range lc {
+			if c != q[i] {
+				continue
+			}
+
+			bonus := matchScore
+			if isWordBoundary(oc, j) {
+				bonus += boundaryBonus
+			}
+
+			if i == 0 {
+				cur[j] = dpState{score: bonus, start: j}
+				continue
+			}
+
+			best := dpState{score: matchNegInf}
+			if j > 0 && prev[j-1].score > matchNegInf {
+				best = dpState{score: prev[j-1].score + consecutiveBonus, start: prev[j-1].start}
+			}
+			if j >= 2 && prefixBest[j-2].score > matchNegInf {
+				gapped := dpState{score: prefixBest[j-2].score - gapPenalty, start: prefixBest[j-2].start}
+				if gapped.better(best) {
+					best = gapped
+				}
+			}
+			if best.score == matchNegInf {
+				continue
+			}
+			cur[j] = dpState{score: bonus + best.score, start: best.start}
+		}
+
+		prev, cur = cur, prev
+	}
+
+	best := dpState{score: matchNegInf}
+	bestEnd := -1
+	for j, st := range prev {
+		if st.score == matchNegInf {
+			continue
+		}
+		if best.score == matchNegInf ||
+			st.score > best.score ||
+			(st.score == best.score && j-st.start < bestEnd-best.start) {
+			best, bestEnd = st, j
+		}
+	}
+
+	if best.score == matchNegInf {
+		return matchResult{}, false
+	}
+	return matchResult{score: best.score, span: bestEnd - best.start + 1}, true
+}
+
+// matchAlias reports whether query fuzzy-matches any of a's Name, Cmd, or
+// Desc fields, returning the best (highest-scoring) match found.
+func matchAlias(query string, a parser.Alias) (matchResult, bool) {
+	var best matchResult
+	matched := false
+	for _, field := range [...]string{a.Name, a.Cmd, a.Desc} {
+		if m, ok := fuzzyMatch(query, field); ok {
+			if !matched || m.score > best.score {
+				best = m
+				matched = true
+			}
+		}
+	}
+	return best, matched
+}
+
+// filterSections returns the subset of sm whose aliases fuzzy-match query,
+// dropping any section left with no aliases. An empty query returns sm
+// unchanged.
+func filterSections(sm map[string]parser.Section, query string) map[string]parser.Section {
+	if query == "" {
+		return sm
+	}
+
+	fm := make(map[string]parser.Section)
+	for sl, sn := range sm {
+		as := make(map[string]parser.Alias)
+		for an, a := range sn.Aliases {
+			if _, ok := matchAlias(query, a); ok {
+				as[an] = a
+			}
+		}
+		if len(as) > 0 {
+			fm[sl] = parser.Section{Label: sn.Label, Aliases: as}
+		}
+	}
+	return fm
+}
+
+// rankedAliasNames returns sn's alias names ordered by fuzzy match quality
+// against query (best first). Ties are broken by shorter match span, then by
+// shorter alias name, then alphabetically.
+func rankedAliasNames(query string, sn parser.Section) []string {
+	type scored struct {
+		name string
+		m    matchResult
+	}
+
+	ss := make([]scored, 0, len(sn.Aliases))
+	for an, a := range sn.Aliases {
+		m, _ := matchAlias(query, a)
+		ss = append(ss, scored{an, m})
+	}
+
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].m.score != ss[j].m.score {
+			return ss[i].m.score > ss[j].m.score
+		}
+		if ss[i].m.span != ss[j].m.span {
+			return ss[i].m.span < ss[j].m.span
+		}
+		if len(ss[i].name) != len(ss[j].name) {
+			return len(ss[i].name) < len(ss[j].name)
+		}
+		return ss[i].name < ss[j].name
+	})
+
+	names := make([]string, len(ss))
+	for i, s := range ss {
+		names[i] = s.name
+	}
+	return names
+}
@@ -0,0 +1,83 @@
+package panel
+
+import "testing"
+
+func TestFuzzyMatch(t *testing.T) {
+	cases := []struct {
+		name      string
+		query     string
+		candidate string
+		wantOK    bool
+		wantScore int
+		wantSpan  int
+	}{
+		{
+			name:      "empty query matches everything with a zero score",
+			query:     "",
+			candidate: "anything",
+			wantOK:    true,
+			wantScore: 0,
+			wantSpan:  0,
+		},
+		{
+			name:      "no match",
+			query:     "xyz",
+			candidate: "abc",
+			wantOK:    false,
+		},
+		{
+			name:      "exact match scores the leading boundary and every consecutive run",
+			query:     "abc",
+			candidate: "abc",
+			wantOK:    true,
+			wantScore: 64, // (16+8) + (16+4) + (16+4)
+			wantSpan:  3,
+		},
+		{
+			name:      "repeated letters: picks the best-scoring alignment, not the first one",
+			query:     "abc",
+			candidate: "aXbXabcX",
+			wantOK:    true,
+			wantScore: 59,
+			wantSpan:  7,
+		},
+		{
+			name:      "match after a separator earns the word-boundary bonus despite the gap",
+			query:     "gc",
+			candidate: "git commit",
+			wantOK:    true,
+			wantScore: 47,
+			wantSpan:  5,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := fuzzyMatch(tc.query, tc.candidate)
+			if ok != tc.wantOK {
+				t.Fatalf("fuzzyMatch(%q, %q) ok = %v, want %v", tc.query, tc.candidate, ok, tc.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.score != tc.wantScore || got.span != tc.wantSpan {
+				t.Errorf("fuzzyMatch(%q, %q) = %+v, want {score:%d span:%d}", tc.query, tc.candidate, got, tc.wantScore, tc.wantSpan)
+			}
+		})
+	}
+}
+
+func TestFuzzyMatchPrefersHigherScoringAlignment(t *testing.T) {
+	// A purely greedy, first-occurrence scan would match "abc" against
+	// positions 0, 2, 6 here (score 54, span 7) and never consider the
+	// contiguous "abc" at positions 4-6 (score 56, span 3), let alone the
+	// true best alignment. fuzzyMatch must do better than the greedy scan.
+	got, ok := fuzzyMatch("abc", "aXbXabcX")
+	if !ok {
+		t.Fatal("fuzzyMatch(\"abc\", \"aXbXabcX\") = not ok, want a match")
+	}
+	const greedyScore = 54
+	if got.score <= greedyScore {
+		t.Errorf("fuzzyMatch(\"abc\", \"aXbXabcX\") score = %d, want > %d (the greedy scan's score)", got.score, greedyScore)
+	}
+}
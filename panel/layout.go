@@ -0,0 +1,224 @@
+package panel
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/BradyBolton/alias-panel/parser"
+)
+
+// sectionView holds a single Section's user-adjustable layout state: its
+// resized width (0 meaning "use the computed default"), whether it is
+// collapsed to just its header, and how far its alias list has been
+// scrolled.
+type sectionView struct {
+	Width        int  `json:"width"`
+	Collapsed    bool `json:"collapsed"`
+	ScrollOffset int  `json:"scrollOffset"`
+}
+
+// Layout tracks each Section's sectionView, keyed by label, persisting it
+// to $XDG_STATE_HOME/alias-panel/layout.json so arrangements survive
+// restarts.
+type Layout struct {
+	views map[string]*sectionView
+	dirty bool
+}
+
+// layoutPath returns the path layout.json is persisted to.
+func layoutPath() (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(base, "alias-panel", "layout.json"), nil
+}
+
+// loadLayout reads the persisted Layout, or returns an empty one if none
+// exists yet (or it can't be read).
+func loadLayout() *Layout {
+	l := &Layout{views: make(map[string]*sectionView)}
+
+	p, err := layoutPath()
+	if err != nil {
+		log.Error(err)
+		return l
+	}
+
+	data, err := os.ReadFile(p)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Error(err)
+		}
+		return l
+	}
+
+	if err := json.Unmarshal(data, &l.views); err != nil {
+		log.Error(err)
+	}
+	return l
+}
+
+// save persists the Layout to layoutPath().
+func (l *Layout) save() {
+	p, err := layoutPath()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		log.Error(err)
+		return
+	}
+
+	data, err := json.MarshalIndent(l.views, "", "  ")
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if err := os.WriteFile(p, data, 0644); err != nil {
+		log.Error(err)
+	}
+}
+
+// view returns label's sectionView, creating a default one if it doesn't
+// exist yet.
+func (l *Layout) view(label string) *sectionView {
+	v, ok := l.views[label]
+	if !ok {
+		v = &sectionView{}
+		l.views[label] = v
+	}
+	return v
+}
+
+// setWidth resizes label's section to w columns. It only marks the Layout
+// dirty rather than persisting immediately, since it's called on every
+// mouse-move event of a resize drag; call flush() once the drag ends.
+func (l *Layout) setWidth(label string, w int) {
+	l.view(label).Width = w
+	l.dirty = true
+}
+
+// flush persists the Layout if setWidth has marked it dirty since the last
+// save, and is a no-op otherwise. Call this on mouse-button-release to
+// batch a whole drag gesture into a single write.
+func (l *Layout) flush() {
+	if !l.dirty {
+		return
+	}
+	l.dirty = false
+	l.save()
+}
+
+// toggleCollapsed flips label's collapsed state and persists the change.
+func (l *Layout) toggleCollapsed(label string) {
+	v := l.view(label)
+	v.Collapsed = !v.Collapsed
+	l.save()
+}
+
+// scroll moves label's ScrollOffset by delta lines and persists the
+// change. total is the section's current alias count; the offset is
+// clamped to [0, max(0, total-1)] so scrolling down pages through the
+// list without ever scrolling past the last entry into a blank section.
+func (l *Layout) scroll(label string, delta, total int) {
+	v := l.view(label)
+	v.ScrollOffset += delta
+	if v.ScrollOffset < 0 {
+		v.ScrollOffset = 0
+	}
+	if max := total - 1; max < 0 {
+		v.ScrollOffset = 0
+	} else if v.ScrollOffset > max {
+		v.ScrollOffset = max
+	}
+	l.save()
+}
+
+// sectionWidth returns the effective width a section should be drawn at,
+// given the column's default width dw: the section's own override (if any)
+// clamped to [minPanelWidth, maxPanelWidth] so it can both shrink and grow,
+// never collapsing below a usable width or sprawling past what a panel can
+// reasonably take up.
+func (l *Layout) sectionWidth(label string, dw int) int {
+	w := l.view(label).Width
+	if w <= 0 {
+		return dw
+	}
+	if w < minPanelWidth {
+		w = minPanelWidth
+	}
+	if w > maxPanelWidth {
+		w = maxPanelWidth
+	}
+	return w
+}
+
+// sectionAliasCount returns how many of label's aliases currently match
+// query, i.e. the length of the list drawPanels renders for label once sm
+// has been narrowed by filterSections.
+// Used to clamp scrolling to the section's actual (filtered) length.
+func sectionAliasCount(sm map[string]parser.Section, label, query string) int {
+	sn, ok := filterSections(sm, query)[label]
+	if !ok {
+		return 0
+	}
+	return len(sn.Aliases)
+}
+
+// sectionDisplayOrder returns sn's alias names in the order they are
+// rendered: alphabetical, or ranked by fuzzy match quality if query is
+// non-empty.
+func sectionDisplayOrder(sn parser.Section, query string) []string {
+	if query == "" {
+		as := make([]string, 0, len(sn.Aliases))
+		for an := range sn.Aliases {
+			as = append(as, an)
+		}
+		sort.Strings(as)
+		return as
+	}
+	return rankedAliasNames(query, sn)
+}
+
+// applyScroll drops the first offset entries of names, clamped to
+// [0, max(0, len(names)-1)] so a scrolled section pages through its
+// overflowing list without ever scrolling past the last entry into a
+// blank body.
+func applyScroll(names []string, offset int) []string {
+	if offset < 0 {
+		offset = 0
+	}
+	if max := len(names) - 1; max < 0 {
+		offset = 0
+	} else if offset > max {
+		offset = max
+	}
+	return names[offset:]
+}
+
+// sectionRect records where a Section was last drawn, so mouse events can
+// be hit-tested against it.
+type sectionRect struct {
+	label      string
+	x, y, w, h int
+}
+
+// hitTest returns the sectionRect (if any) containing (x, y).
+func hitTest(rects []sectionRect, x, y int) (sectionRect, bool) {
+	for _, r := range rects {
+		if x >= r.x && x < r.x+r.w && y >= r.y && y < r.y+r.h {
+			return r, true
+		}
+	}
+	return sectionRect{}, false
+}
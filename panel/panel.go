@@ -11,6 +11,7 @@ import (
 	"github.com/mattn/go-runewidth"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/BradyBolton/alias-panel/panel/clipboard"
 	"github.com/BradyBolton/alias-panel/parser"
 )
 
@@ -135,8 +136,12 @@ func minHeight(w int, t string) int {
 }
 
 // drawSection draws a single (w x h) sized box for Section, with the UL corner
-// at (x, y), a label, and body text.
-func drawSection(s tcell.Screen, x, y, w, h int, sn parser.Section) error {
+// at (x, y), a label, and body text. Aliases are listed alphabetically unless
+// query is non-empty, in which case they are ranked by fuzzy match quality
+// against query instead. The alias matching sel (if any) is drawn reversed
+// to show it as the current cursor position. If sv is collapsed, only the
+// label is drawn; otherwise its ScrollOffset pages through the alias list.
+func drawSection(s tcell.Screen, x, y, w, h int, sn parser.Section, query string, sel Selection, sv *sectionView) error {
 	if x < 0 || y < 0 || w < 0 || h < 0 {
 		return errors.New("x, y, w, and h cannot be negative")
 	}
@@ -160,18 +165,17 @@ func drawSection(s tcell.Screen, x, y, w, h int, sn parser.Section) error {
 	emitStr(s, lx, y, st, label)
 	s.Show()
 
+	if sv.Collapsed {
+		return nil
+	}
+
 	// Draw body text
 	ax := x + 1
 	ay := y + 1
 	aw := w - 2
 	var ah int
 
-	// Iterate through the aliases in alphabetical order
-	as := make([]string, 0)
-	for an := range sn.Aliases {
-		as = append(as, an)
-	}
-	sort.Strings(as)
+	as := applyScroll(sectionDisplayOrder(sn, query), sv.ScrollOffset)
 	for _, an := range as {
 		a := sn.Aliases[an]
 		btext := a.Name + ": " + a.Cmd
@@ -182,8 +186,12 @@ func drawSection(s tcell.Screen, x, y, w, h int, sn parser.Section) error {
 			break
 		}
 
-		// Otherwise print the alias
-		err := drawTextBox(s, ax, ay, aw, ah, st, btext)
+		// Otherwise print the alias, reversed if it is the current selection
+		bst := st
+		if sn.Label == sel.Section && an == sel.Name {
+			bst = st.Reverse(true)
+		}
+		err := drawTextBox(s, ax, ay, aw, ah, bst, btext)
 		if err != nil {
 			log.Errorf("drawSection: Issue parsing (%v)", err)
 		}
@@ -224,24 +232,86 @@ func maxColumnWidth(w, nc, m int) int {
 	return pw
 }
 
-// Draw panels for in the terminal, one for each section in Section map sm with
-// margin m.
-func drawPanels(s tcell.Screen, sm map[string]parser.Section, m int) {
+// drawFilterPrompt draws the filter query prompt on row y.
+func drawFilterPrompt(s tcell.Screen, y int, query string) {
+	st := tcell.StyleDefault.
+		Foreground(tcell.ColorYellow)
+	emitStr(s, 0, y, st, "> "+query)
+}
+
+// previewWidthFrac is the fraction of the window width reserved for the
+// preview pane (modeled on fzf's default right-hand preview window).
+const previewWidthFrac = 0.4
+
+// previewBounds computes the preview pane's on-screen rectangle for a
+// (w x h) screen with margin m, assuming the preview pane is enabled.
+func previewBounds(w, h, m int) (x, y, pw, ph int) {
+	cw := w - int(float64(w)*previewWidthFrac) - m
+	return cw + m, m, w - cw - m, h - m
+}
+
+// eraseRect blanks out a (w x h) area at (x, y) with the default style, so
+// redraw can erase just the regions it's about to repaint instead of
+// clearing the whole screen.
+func eraseRect(s tcell.Screen, x, y, w, h int) {
+	st := tcell.StyleDefault
+	for row := y; row < y+h; row++ {
+		for col := x; col < x+w; col++ {
+			s.SetContent(col, row, ' ', nil, st)
+		}
+	}
+}
+
+// eraseFrame blanks out exactly the regions the previous drawPanels call
+// drew to -- each section rect, the preview pane, the help message, and the
+// filter prompt row -- computed from the screen's previous (w, h). This
+// lets redraw repaint without a blanket s.Clear().
+func eraseFrame(s tcell.Screen, rects []sectionRect, w, h, m int, previewEnabled bool) {
+	for _, r := range rects {
+		eraseRect(s, r.x, r.y, r.w, r.h)
+	}
+	// help message row
+	eraseRect(s, 0, 0, w, 1)
+	// drawPanels reserves the bottom row for the filter prompt
+	fh := h - 1
+	eraseRect(s, 0, fh, w, 1)
+	if previewEnabled {
+		px, py, pw, ph := previewBounds(w, fh, m)
+		eraseRect(s, px, py, pw, ph)
+	}
+}
+
+// Draw panels for in the terminal, one for each section in Section map sm
+// with margin m, narrowed down to those matching query (if non-empty). When
+// ps is enabled, a preview pane for the alias at sel is reserved on the
+// right of the screen. layout supplies each section's resized width,
+// collapsed state, and scroll offset. It returns where each section was
+// drawn, for mouse hit-testing. Callers are expected to erase the previous
+// frame's regions (see eraseFrame) themselves; drawPanels only draws.
+func drawPanels(s tcell.Screen, sm map[string]parser.Section, m int, query string, sel Selection, ps *previewState, layout *Layout) []sectionRect {
 	w, h := s.Size()
 
 	// Render nothing if space is too small
 	if w < minWindowWidth || h < minWindowHeight {
-		return
+		return nil
 	}
+	h-- // reserve the bottom row for the filter prompt
 
 	// Help message
 	st := tcell.StyleDefault.
 		Foreground(tcell.ColorWhite)
-	msg := "Press [Q]uit to exit"
+	msg := "Press [Esc] to quit"
 	emitStr(s, w-len(msg), 0, st, msg)
 
-	nc := maxColumns(w, minPanelWidth, maxPanelWidth)
-	pw := maxColumnWidth(w, nc, m)
+	sm = filterSections(sm, query)
+
+	cw := w
+	if ps.enabled() {
+		cw = w - int(float64(w)*previewWidthFrac) - m
+	}
+
+	nc := maxColumns(cw, minPanelWidth, maxPanelWidth)
+	pw := maxColumnWidth(cw, nc, m)
 
 	// Iterate through the sections in alphabetical order (label)
 	sls := make([]string, 0)
@@ -250,43 +320,103 @@ func drawPanels(s tcell.Screen, sm map[string]parser.Section, m int) {
 	}
 	sort.Strings(sls)
 
+	// First pass: walk the same column-filling order as before to decide
+	// which column and row each section lands in, and how wide and tall it
+	// wants to be. This has to happen before anything is drawn because a
+	// section resized wider than pw widens its whole column, and a column's
+	// width -- and so where every column to its right starts -- depends on
+	// every section sharing that column, not just this one.
+	type placement struct {
+		label      string
+		col        int
+		py, ew, bh int
+	}
+	var placements []placement
+	colWidth := make([]int, nc)
+	for i := range colWidth {
+		colWidth[i] = pw
+	}
+
 	p := 0
 loop:
 	for c := 0; c < nc; c++ { // Fill columns top to bottom left to right
 		py := m
-		px := m + (pw+m)*(c)
 
 		for py < h {
-			// Stop if all panels are drawn
+			// Stop if all panels are placed
 			if p >= len(sm) {
 				break loop
 			}
 			sl := sls[p]
+			sv := layout.view(sl)
+			ew := layout.sectionWidth(sl, pw)
 
 			// Stop column if no more vertical space
 			bh := 0 // body text height
-			for _, a := range sm[sl].Aliases {
-				btext := a.Name + ": " + a.Cmd
-				bh += minHeight(pw-2, btext)
+			if !sv.Collapsed {
+				names := applyScroll(sectionDisplayOrder(sm[sl], query), sv.ScrollOffset)
+				for _, an := range names {
+					a := sm[sl].Aliases[an]
+					btext := a.Name + ": " + a.Cmd
+					bh += minHeight(ew-2, btext)
+				}
 			}
 			if py+bh-1 >= (h - 2) {
 				continue loop
 			}
 
-			// Otherwise draw the new section
-			err := drawSection(s, px, py, pw, bh+2, sm[sl])
-			if err != nil {
-				log.Error(err)
-				return
+			placements = append(placements, placement{label: sl, col: c, py: py, ew: ew, bh: bh})
+			if ew > colWidth[c] {
+				colWidth[c] = ew
 			}
 			py += bh + 2
 			p++
 		}
 	}
+
+	// colX[c] is where column c starts, each offset by the actual (possibly
+	// grown) width of every column before it, so a widened section never
+	// overlaps the column to its right.
+	colX := make([]int, nc)
+	x := m
+	for c := range colX {
+		colX[c] = x
+		x += colWidth[c] + m
+	}
+
+	var rects []sectionRect
+	for _, pl := range placements {
+		sv := layout.view(pl.label)
+		px := colX[pl.col]
+		err := drawSection(s, px, pl.py, pl.ew, pl.bh+2, sm[pl.label], query, sel, sv)
+		if err != nil {
+			log.Error(err)
+			return rects
+		}
+		rects = append(rects, sectionRect{label: pl.label, x: px, y: pl.py, w: pl.ew, h: pl.bh + 2})
+	}
+
+	if ps.enabled() {
+		px, py, ppw, pph := previewBounds(w, h, m)
+		if a, ok := resolveSelection(sm, sel); ok {
+			if err := drawPreviewPane(s, px, py, ppw, pph, a, ps); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+
+	drawFilterPrompt(s, h, query)
+
+	return rects
 }
 
-// Draw panels on screen for sections in Section map sm with margin m.
-func DrawScreen(sm map[string]parser.Section, m int) {
+// Draw panels on screen for sections in Section map sm with margin m. If
+// previewCmd is non-empty, a preview pane is reserved for the selected
+// alias and previewCmd is run against it (with {name} substituted) whenever
+// the selection settles. Pressing Enter writes the selected alias's Cmd to
+// execFD (NUL-terminated if print0 is set) and exits; pressing 'y' copies
+// Cmd to the system clipboard instead.
+func DrawScreen(sm map[string]parser.Section, m int, previewCmd string, execFD int, print0 bool) {
 	tcell.SetEncodingFallback(tcell.EncodingFallbackASCII)
 
 	s, err := tcell.NewScreen()
@@ -303,6 +433,42 @@ func DrawScreen(sm map[string]parser.Section, m int) {
 	s.SetStyle(tcell.StyleDefault.
 		Foreground(tcell.ColorWhite))
 	s.Clear()
+	s.EnableMouse(tcell.MouseButtonEvents | tcell.MouseDragEvents)
+
+	fs := &filterState{}
+	ps := newPreviewState(previewCmd)
+	layout := loadLayout()
+	var sel Selection
+	var rects []sectionRect
+	var prevW, prevH int
+	var mouseDown, dragging bool
+	var dragLabel string
+	var dragOriginX int
+
+	// redraw re-homes sel if it fell out of the filtered results, kicks off
+	// a (debounced) preview refresh for it, and repaints the screen -- by
+	// erasing only the regions the previous frame drew to, rather than a
+	// blanket s.Clear().
+	redraw := func() {
+		visible := false
+		for _, v := range visibleAliases(sm, fs.String(), layout) {
+			if v == sel {
+				visible = true
+				break
+			}
+		}
+		if !visible {
+			sel = firstSelection(sm, fs.String(), layout)
+		}
+		if a, ok := resolveSelection(sm, sel); ok {
+			ps.refresh(s, a)
+		}
+		if prevW > 0 && prevH > 0 {
+			eraseFrame(s, rects, prevW, prevH, m, ps.enabled())
+		}
+		rects = drawPanels(s, sm, m, fs.String(), sel, ps, layout)
+		prevW, prevH = s.Size()
+	}
 
 	quit := make(chan struct{})
 	go func() {
@@ -313,16 +479,115 @@ func DrawScreen(sm map[string]parser.Section, m int) {
 				switch ev.Key() {
 				case tcell.KeyCtrlL:
 					s.Sync()
-				default:
-					if ev.Rune() == 'Q' || ev.Rune() == 'q' {
+				case tcell.KeyCtrlC:
+					close(quit)
+					return
+				case tcell.KeyEnter:
+					if a, ok := resolveSelection(sm, sel); ok && execFD > 0 {
+						writeExecute(execFD, print0, a)
+					}
+					close(quit)
+					return
+				case tcell.KeyEscape:
+					if fs.String() == "" {
 						close(quit)
 						return
 					}
+					fs.clear()
+					redraw()
+				case tcell.KeyCtrlU:
+					fs.clear()
+					redraw()
+				case tcell.KeyBackspace, tcell.KeyBackspace2:
+					fs.backspace()
+					redraw()
+				case tcell.KeyUp:
+					sel = moveSelection(sm, fs.String(), sel, -1, layout)
+					redraw()
+				case tcell.KeyDown:
+					sel = moveSelection(sm, fs.String(), sel, 1, layout)
+					redraw()
+				case tcell.KeyLeft:
+					sel = moveSection(sm, fs.String(), sel, -1, layout)
+					redraw()
+				case tcell.KeyRight:
+					sel = moveSection(sm, fs.String(), sel, 1, layout)
+					redraw()
+				case tcell.KeyRune:
+					// hjkl move the cursor like the arrow keys above, but
+					// only while no query has been typed yet -- once a
+					// query is active, every printable rune (hjkl
+					// included) is filter input instead.
+					switch r := ev.Rune(); {
+					case fs.String() == "" && r == 'k':
+						sel = moveSelection(sm, fs.String(), sel, -1, layout)
+					case fs.String() == "" && r == 'j':
+						sel = moveSelection(sm, fs.String(), sel, 1, layout)
+					case fs.String() == "" && r == 'h':
+						sel = moveSection(sm, fs.String(), sel, -1, layout)
+					case fs.String() == "" && r == 'l':
+						sel = moveSection(sm, fs.String(), sel, 1, layout)
+					case fs.String() == "" && r == 'y':
+						if a, ok := resolveSelection(sm, sel); ok {
+							if err := clipboard.CopyTTY(a.Cmd); err != nil {
+								log.Error(err)
+							}
+						}
+					default:
+						fs.appendRune(r)
+					}
+					redraw()
+				}
+			case *tcell.EventMouse:
+				x, y := ev.Position()
+				btns := ev.Buttons()
+
+				if btns&tcell.WheelUp != 0 {
+					if r, ok := hitTest(rects, x, y); ok {
+						layout.scroll(r.label, -1, sectionAliasCount(sm, r.label, fs.String()))
+						redraw()
+					}
+					break
+				}
+				if btns&tcell.WheelDown != 0 {
+					if r, ok := hitTest(rects, x, y); ok {
+						layout.scroll(r.label, 1, sectionAliasCount(sm, r.label, fs.String()))
+						redraw()
+					}
+					break
+				}
+
+				if btns&tcell.Button1 == 0 {
+					if dragging {
+						layout.flush()
+					}
+					mouseDown, dragging = false, false
+					break
+				}
+				if !mouseDown {
+					mouseDown = true
+					if r, ok := hitTest(rects, x, y); ok {
+						switch {
+						case x == r.x+r.w-1:
+							dragging, dragLabel, dragOriginX = true, r.label, r.x
+						case y == r.y:
+							layout.toggleCollapsed(r.label)
+							redraw()
+						}
+					}
+				} else if dragging {
+					layout.setWidth(dragLabel, x-dragOriginX+1)
+					redraw()
 				}
 			case *tcell.EventResize:
 				s.Sync()
-				s.Clear()
-				drawPanels(s, sm, m)
+				redraw()
+			case *tcell.EventInterrupt:
+				if prevW > 0 && prevH > 0 {
+					eraseFrame(s, rects, prevW, prevH, m, ps.enabled())
+				}
+				rects = drawPanels(s, sm, m, fs.String(), sel, ps, layout)
+				prevW, prevH = s.Size()
 			}
 		}
 	}()
@@ -0,0 +1,122 @@
+package panel
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/BradyBolton/alias-panel/parser"
+)
+
+// previewDebounce is how long to wait after the last selection change
+// before running the preview command, so rapid navigation doesn't spawn a
+// subprocess per keystroke.
+const previewDebounce = 150 * time.Millisecond
+
+// previewState holds the preview command template (from the --preview flag)
+// and the cached output of its most recent run.
+type previewState struct {
+	cmdTmpl string // e.g. "type {name}"; empty disables the subprocess
+
+	mu     sync.Mutex
+	output string
+	timer  *time.Timer
+}
+
+// newPreviewState builds a previewState from the --preview flag value, or
+// returns nil if the preview pane should be disabled entirely.
+func newPreviewState(cmdTmpl string) *previewState {
+	if cmdTmpl == "" {
+		return nil
+	}
+	return &previewState{cmdTmpl: cmdTmpl}
+}
+
+// enabled reports whether the preview pane should be drawn at all.
+func (ps *previewState) enabled() bool {
+	return ps != nil
+}
+
+// refresh debounces and (re)schedules a run of the preview command against
+// a, substituting {name} with a.Name. Once output is captured, it posts an
+// interrupt event to s so the main loop knows to redraw.
+func (ps *previewState) refresh(s tcell.Screen, a parser.Alias) {
+	if ps == nil {
+		return
+	}
+	if ps.timer != nil {
+		ps.timer.Stop()
+	}
+
+	cmd := strings.ReplaceAll(ps.cmdTmpl, "{name}", a.Name)
+	ps.timer = time.AfterFunc(previewDebounce, func() {
+		out, err := exec.Command("sh", "-c", cmd).Output()
+		ps.mu.Lock()
+		if err != nil {
+			ps.output = err.Error()
+		} else {
+			ps.output = string(out)
+		}
+		ps.mu.Unlock()
+		s.PostEvent(tcell.NewEventInterrupt(nil))
+	})
+}
+
+// String returns the most recently captured preview command output.
+func (ps *previewState) String() string {
+	if ps == nil {
+		return ""
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.output
+}
+
+// drawPreviewPane draws a's Cmd (wrapped), Desc, and any captured preview
+// command output in a (w x h) box with the UL corner at (x, y).
+func drawPreviewPane(s tcell.Screen, x, y, w, h int, a parser.Alias, ps *previewState) error {
+	if w < 0 || h < 0 {
+		return errors.New("w and h cannot be negative")
+	}
+
+	st := tcell.StyleDefault.
+		Foreground(tcell.ColorWhite)
+	drawBox(s, x, y, w, h, st)
+
+	ltext, err := truncate(a.Name, w-4)
+	if err != nil {
+		return err
+	}
+	label := "[" + ltext + "]"
+	lx := x + (w-len(label))/2
+	emitStr(s, lx, y, tcell.StyleDefault.Foreground(tcell.ColorRed), label)
+
+	ax, ay, aw := x+1, y+1, w-2
+
+	ah := minHeight(aw, a.Cmd)
+	if err := drawTextBox(s, ax, ay, aw, ah, st, a.Cmd); err != nil {
+		return err
+	}
+	ay += ah
+
+	if a.Desc != "" && ay-y < h-1 {
+		dh := minHeight(aw, a.Desc)
+		if err := drawTextBox(s, ax, ay, aw, dh, st, a.Desc); err != nil {
+			return err
+		}
+		ay += dh
+	}
+
+	if out := ps.String(); out != "" && ay-y < h-1 {
+		oh := minHeight(aw, out)
+		if err := drawTextBox(s, ax, ay, aw, oh, st, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
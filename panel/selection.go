@@ -0,0 +1,145 @@
+package panel
+
+import (
+	"sort"
+
+	"github.com/BradyBolton/alias-panel/parser"
+)
+
+// Selection identifies a single alias by section label and name, i.e. the
+// cursor position within the panels currently on screen.
+type Selection struct {
+	Section string
+	Name    string
+}
+
+// visibleAliases returns every (section, alias name) pair matching query, in
+// the same section-then-alias order drawPanels renders them in. Sections
+// collapsed in layout are skipped entirely, since drawSection doesn't draw
+// their aliases.
+func visibleAliases(sm map[string]parser.Section, query string, layout *Layout) []Selection {
+	sm = filterSections(sm, query)
+
+	sls := make([]string, 0, len(sm))
+	for sl := range sm {
+		sls = append(sls, sl)
+	}
+	sort.Strings(sls)
+
+	var vs []Selection
+	for _, sl := range sls {
+		if layout.view(sl).Collapsed {
+			continue
+		}
+		sn := sm[sl]
+
+		var as []string
+		if query == "" {
+			as = make([]string, 0, len(sn.Aliases))
+			for an := range sn.Aliases {
+				as = append(as, an)
+			}
+			sort.Strings(as)
+		} else {
+			as = rankedAliasNames(query, sn)
+		}
+
+		for _, an := range as {
+			vs = append(vs, Selection{Section: sl, Name: an})
+		}
+	}
+	return vs
+}
+
+// firstSelection returns the first visible alias for query, or the zero
+// Selection if none match.
+func firstSelection(sm map[string]parser.Section, query string, layout *Layout) Selection {
+	vs := visibleAliases(sm, query, layout)
+	if len(vs) == 0 {
+		return Selection{}
+	}
+	return vs[0]
+}
+
+// moveSelection steps cur by delta positions through the flattened, ordered
+// list of visible aliases, clamped to the list's bounds. If cur is no
+// longer visible, it returns the first visible alias instead.
+func moveSelection(sm map[string]parser.Section, query string, cur Selection, delta int, layout *Layout) Selection {
+	vs := visibleAliases(sm, query, layout)
+	if len(vs) == 0 {
+		return Selection{}
+	}
+
+	i := -1
+	for idx, v := range vs {
+		if v == cur {
+			i = idx
+			break
+		}
+	}
+	if i < 0 {
+		return vs[0]
+	}
+
+	i += delta
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(vs) {
+		i = len(vs) - 1
+	}
+	return vs[i]
+}
+
+// moveSection jumps cur to the first visible alias of the delta-th
+// neighboring section (e.g. delta=1 for the next section, -1 for the
+// previous one).
+func moveSection(sm map[string]parser.Section, query string, cur Selection, delta int, layout *Layout) Selection {
+	vs := visibleAliases(sm, query, layout)
+	if len(vs) == 0 {
+		return Selection{}
+	}
+
+	var sls []string
+	seen := make(map[string]bool)
+	for _, v := range vs {
+		if !seen[v.Section] {
+			seen[v.Section] = true
+			sls = append(sls, v.Section)
+		}
+	}
+
+	ci := 0
+	for idx, sl := range sls {
+		if sl == cur.Section {
+			ci = idx
+			break
+		}
+	}
+
+	ci += delta
+	if ci < 0 {
+		ci = 0
+	}
+	if ci >= len(sls) {
+		ci = len(sls) - 1
+	}
+
+	target := sls[ci]
+	for _, v := range vs {
+		if v.Section == target {
+			return v
+		}
+	}
+	return Selection{}
+}
+
+// resolveSelection looks up the Alias that sel refers to within sm.
+func resolveSelection(sm map[string]parser.Section, sel Selection) (parser.Alias, bool) {
+	sn, ok := sm[sel.Section]
+	if !ok {
+		return parser.Alias{}, false
+	}
+	a, ok := sn.Aliases[sel.Name]
+	return a, ok
+}
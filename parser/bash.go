@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// bashSource parses plain bash/POSIX alias declarations:
+//
+//	alias name='cmd'
+//	alias name='cmd' # comment
+type bashSource struct{}
+
+var (
+	bashAliasWithComment = regexp.MustCompile(`alias (?P<name>[_a-zA-Z0-9]+)=['"](?P<command>.+)['"][^#]*#(?P<comment>.+)$`)
+	bashAlias            = regexp.MustCompile(`alias (?P<name>[_a-zA-Z0-9]+)=['"](?P<command>.+)['"]$`)
+)
+
+// parseBashAlias returns Alias parsed from string s (or the zero Alias if s
+// isn't an alias declaration).
+func parseBashAlias(s string) (Alias, error) {
+	// Make two passes to capture the (optional) comment since Go's regexp
+	// package has no conditional groups (unlike, say, JS's regexp engine)
+	if m := bashAliasWithComment.FindStringSubmatch(s); m != nil {
+		n := m[1]                    // name
+		c := m[2]                    // command
+		d := strings.TrimSpace(m[3]) // description/comment
+		log.Debugf("parseBashAlias: %v-%v-%v", n, c, d)
+		return Alias{Name: n, Cmd: c, Desc: d}, nil
+	}
+
+	if m := bashAlias.FindStringSubmatch(s); m != nil {
+		n := m[1] // name
+		c := m[2] // command
+		log.Debugf("parseBashAlias: %v-%v", n, c)
+		return Alias{Name: n, Cmd: c}, nil
+	}
+
+	log.Debugf("parseBashAlias: Skipping %v", s)
+	return Alias{}, nil
+}
+
+func (bashSource) Parse(r io.Reader) (map[string]Section, error) {
+	sb := newSectionBuilder()
+	err := scanLines(r, sb.setSection, func(line string) error {
+		a, err := parseBashAlias(line)
+		if err != nil {
+			return err
+		}
+		sb.add(a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sb.result(), nil
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// fishSource parses fish alias and abbreviation declarations:
+//
+//	alias name 'cmd'
+//	abbr -a name cmd
+type fishSource struct{}
+
+var (
+	fishAliasWithComment = regexp.MustCompile(`^alias (?P<name>[_a-zA-Z0-9]+) ['"](?P<command>.+)['"][^#]*#(?P<comment>.+)$`)
+	fishAlias            = regexp.MustCompile(`^alias (?P<name>[_a-zA-Z0-9]+) ['"](?P<command>.+)['"]$`)
+	fishAbbr             = regexp.MustCompile(`^abbr(?:\s+-a)?\s+(?P<name>[_a-zA-Z0-9]+)\s+(?P<command>.+)$`)
+)
+
+// parseFishLine returns Alias parsed from string s (or the zero Alias if s
+// isn't an alias or abbr declaration).
+func parseFishLine(s string) (Alias, error) {
+	if m := fishAliasWithComment.FindStringSubmatch(s); m != nil {
+		n := m[1]
+		c := m[2]
+		d := strings.TrimSpace(m[3])
+		log.Debugf("parseFishLine: %v-%v-%v", n, c, d)
+		return Alias{Name: n, Cmd: c, Desc: d}, nil
+	}
+
+	if m := fishAlias.FindStringSubmatch(s); m != nil {
+		n := m[1]
+		c := m[2]
+		log.Debugf("parseFishLine: %v-%v", n, c)
+		return Alias{Name: n, Cmd: c}, nil
+	}
+
+	if m := fishAbbr.FindStringSubmatch(s); m != nil {
+		n := m[1]
+		c := m[2]
+		log.Debugf("parseFishLine (abbr): %v-%v", n, c)
+		return Alias{Name: n, Cmd: c, Category: "abbr"}, nil
+	}
+
+	log.Debugf("parseFishLine: Skipping %v", s)
+	return Alias{}, nil
+}
+
+func (fishSource) Parse(r io.Reader) (map[string]Section, error) {
+	sb := newSectionBuilder()
+	err := scanLines(r, sb.setSection, func(line string) error {
+		a, err := parseFishLine(strings.TrimSpace(line))
+		if err != nil {
+			return err
+		}
+		sb.add(a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sb.result(), nil
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// functionSource parses bash/zsh shell function declarations, treating the
+// (brace-balanced, possibly multi-line) body as Cmd and the leading comment
+// block above the declaration as Desc (blank lines between the two do not
+// detach it; any other line does):
+//
+//	# list files, newest first
+//	lt() {
+//		ls -t "$@"
+//	}
+//
+//	function lt {
+//		ls -t "$@"
+//	}
+type functionSource struct{}
+
+var (
+	funcStart   = regexp.MustCompile(`^(?:function\s+(?P<fname>[_a-zA-Z0-9]+)|(?P<name>[_a-zA-Z0-9]+)\s*\(\))\s*\{(?P<rest>.*)$`)
+	commentLine = regexp.MustCompile(`^\s*#\s?(?P<text>.*)$`)
+
+	nameIdx    = funcStart.SubexpIndex("name")
+	fnameIdx   = funcStart.SubexpIndex("fname")
+	restIdx    = funcStart.SubexpIndex("rest")
+	commentIdx = commentLine.SubexpIndex("text")
+)
+
+// trimTrailingBrace removes the closing brace of a function body from the
+// last captured line (if any).
+func trimTrailingBrace(lines []string) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+	last := lines[len(lines)-1]
+	if i := strings.LastIndex(last, "}"); i >= 0 {
+		lines[len(lines)-1] = last[:i]
+	}
+	return lines
+}
+
+func (functionSource) Parse(r io.Reader) (map[string]Section, error) {
+	sb := newSectionBuilder()
+
+	var pendingDesc []string
+	var name string
+	var body []string
+	var depth int
+	capturing := false
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		l := s.Text()
+
+		if label, ok := matchSection(l); ok {
+			sb.setSection(label)
+			continue
+		}
+
+		if capturing {
+			depth += strings.Count(l, "{") - strings.Count(l, "}")
+			body = append(body, l)
+			if depth <= 0 {
+				cmd := strings.TrimSpace(strings.Join(trimTrailingBrace(body), "\n"))
+				sb.add(Alias{Name: name, Cmd: cmd, Desc: strings.Join(pendingDesc, " ")})
+				capturing = false
+				pendingDesc = nil
+				body = nil
+			}
+			continue
+		}
+
+		if m := funcStart.FindStringSubmatch(l); m != nil {
+			name = m[nameIdx]
+			if name == "" {
+				name = m[fnameIdx]
+			}
+			rest := m[restIdx]
+			depth = 1 + strings.Count(rest, "{") - strings.Count(rest, "}")
+			body = nil
+			if strings.TrimSpace(rest) != "" {
+				body = append(body, rest)
+			}
+			if depth <= 0 {
+				cmd := strings.TrimSpace(strings.Join(trimTrailingBrace(body), "\n"))
+				sb.add(Alias{Name: name, Cmd: cmd, Desc: strings.Join(pendingDesc, " ")})
+				pendingDesc = nil
+			} else {
+				capturing = true
+			}
+			continue
+		}
+
+		if m := commentLine.FindStringSubmatch(l); m != nil {
+			pendingDesc = append(pendingDesc, strings.TrimSpace(m[commentIdx]))
+			continue
+		}
+
+		if strings.TrimSpace(l) != "" {
+			// A non-comment line breaks the comment block, so it can no
+			// longer describe a later function.
+			pendingDesc = nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		log.Error(err)
+		return nil, err
+	}
+
+	return sb.result(), nil
+}
@@ -4,6 +4,9 @@ package parser
 
 import (
 	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -16,6 +19,10 @@ type Alias struct {
 	Name string
 	Cmd  string
 	Desc string
+
+	// Category tags an Alias with its shell-specific kind (e.g. "global" or
+	// "suffix" for zsh aliases). Empty for a plain alias.
+	Category string
 }
 
 type Section struct {
@@ -23,111 +30,64 @@ type Section struct {
 	Aliases map[string]Alias
 }
 
-// parseAlias returns Alias parsed from string s (or return an empty struct).
-func parseAlias(s string) (Alias, error) {
-	var a Alias
-	cp := `alias (?P<name>[_a-zA-Z0-9]+)=['"](?P<command>.+)['"][^#]*#(?P<comment>.+)$`
-	ap := `alias (?P<name>[_a-zA-Z0-9]+)=['"](?P<command>.+)['"]$`
-	ra := regexp.MustCompile(ap)
-	rc := regexp.MustCompile(cp)
-
-	// Make two passes to capture (optional) comment since Golang has no
-	// conditional regexp (e.g. like JS)
-	m := rc.FindStringSubmatch(s)
-	if m != nil {
-		n := m[1]                    // name
-		c := m[2]                    // command
-		d := strings.TrimSpace(m[3]) // description/comment
-		log.Debugf("parseAlias: %v-%v-%v", n, c, d)
-		a = Alias{
-			Name: n,
-			Cmd:  c,
-			Desc: d}
-	} else {
-		m = ra.FindStringSubmatch(s)
-		if m != nil {
-			n := m[1] // name
-			c := m[2] // command
-			log.Debugf("parseAlias: %v-%v", n, c)
-			a = Alias{
-				Name: n,
-				Cmd:  c,
-				Desc: ""}
-		} else {
-			log.Debugf("parseAlias: Skipping %v", s)
-			return a, nil
-		}
-	}
-	return a, nil
+// Source parses the aliases (and alias-like constructs, e.g. abbreviations
+// or shell functions) out of r, grouped into Sections.
+type Source interface {
+	Parse(r io.Reader) (map[string]Section, error)
 }
 
-// addAlias processes a line, creating and adding a new Alias to a Section
-// if possible.
-func addAlias(s Section, line string) Section {
-	a, err := parseAlias(line)
-	if err != nil {
-		log.Error(err)
-	} else if a.Name == "" {
-		return s
-	} else {
-		s.Aliases[a.Name] = a
-	}
+// sectionPattern matches a "# SECTION: <label>" marker, recognized across
+// every Source.
+var sectionPattern = regexp.MustCompile(`#\s*SECTION:\s*(?P<label>[a-zA-Z0-9 ]+[^\s])`)
 
-	return s
+// matchSection reports whether line declares a new section, returning its
+// label if so.
+func matchSection(line string) (string, bool) {
+	m := sectionPattern.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
 }
 
-// parseFile produces a map of Sections found in file at path fp.
-func parseFile(fp string) map[string]Section {
-	sm := make(map[string]Section)
-	var cs Section
+// sectionBuilder accumulates Sections (and the "orphan alias" default
+// Section) as a Source scans through a file line by line.
+type sectionBuilder struct {
+	sm  map[string]Section
+	cur Section
+}
 
-	sp := `#\s*SECTION:\s*(?P<label>[a-zA-Z0-9 ]+[^\s])`
-	rs := regexp.MustCompile(sp)
+func newSectionBuilder() *sectionBuilder {
+	return &sectionBuilder{sm: make(map[string]Section)}
+}
 
-	f, err := os.Open(fp)
-	if err != nil {
-		log.Fatal(err)
+// setSection makes label the current section, reusing it if already seen.
+func (sb *sectionBuilder) setSection(label string) {
+	if es, ok := sb.sm[label]; ok {
+		sb.cur = es
+	} else {
+		sb.cur = Section{Label: label, Aliases: make(map[string]Alias)}
+		sb.sm[label] = sb.cur
 	}
-	defer f.Close()
-
-	s := bufio.NewScanner(f)
-	for s.Scan() {
-		l := s.Text()
+}
 
-		// Add new detected sections
-		if m := rs.FindStringSubmatch(l); m != nil {
-			l = m[1]
-			if es, ok := sm[l]; ok {
-				log.Infof("parseFile: Found existing section <%v>", l)
-				cs = es
-			} else {
-				log.Infof("parseFile: Found new section <%v>", l)
-				cs = Section{
-					Label:   l,
-					Aliases: make(map[string]Alias),
-				}
-				sm[l] = cs
-			}
-		} else {
-			// If current section is unset before aliases were found
-			// then use the default Section (for "orphan aliases")
-			if cs.Label == "" {
-				cs = Section{
-					Label:   "Aliases",
-					Aliases: make(map[string]Alias),
-				}
-				sm["Aliases"] = cs
-			}
-			cs = addAlias(cs, l)
-		}
+// add adds a to the current section, falling back to the default "Aliases"
+// section for orphan aliases found before any "# SECTION:" marker. Does
+// nothing if a is the zero Alias.
+func (sb *sectionBuilder) add(a Alias) {
+	if a.Name == "" {
+		return
 	}
-
-	// Abort if any Scanner error was detected
-	if err := s.Err(); err != nil {
-		log.Fatal(err)
+	if sb.cur.Label == "" {
+		sb.setSection("Aliases")
 	}
+	sb.cur.Aliases[a.Name] = a
+	sb.sm[sb.cur.Label] = sb.cur
+}
 
-	return sm
+// result returns the Sections built up so far.
+func (sb *sectionBuilder) result() map[string]Section {
+	return sb.sm
 }
 
 // mergeSections returns the union of two Sections.
@@ -155,6 +115,59 @@ func mergeSectionMaps(a, b map[string]Section) map[string]Section {
 	return a
 }
 
+// firstLine returns the first line of b (without its trailing newline).
+func firstLine(b []byte) string {
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		return string(b[:i])
+	}
+	return string(b)
+}
+
+// detectSource picks the Source responsible for a file's alias syntax,
+// dispatching on its extension, falling back to its shebang line (if any),
+// and finally to plain bash/POSIX alias syntax.
+func detectSource(fp string, content []byte) Source {
+	switch filepath.Ext(fp) {
+	case ".fish":
+		return fishSource{}
+	case ".zsh":
+		return zshSource{}
+	}
+
+	if shebang := firstLine(content); strings.HasPrefix(shebang, "#!") {
+		switch {
+		case strings.Contains(shebang, "zsh"):
+			return zshSource{}
+		case strings.Contains(shebang, "fish"):
+			return fishSource{}
+		}
+	}
+	return bashSource{}
+}
+
+// parseFile produces a map of Sections found in file at path fp, merging
+// the results of its dispatched alias Source with those of functionSource
+// (shell functions are written the same way in both bash and zsh files).
+func parseFile(fp string) map[string]Section {
+	content, err := ioutil.ReadFile(fp)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sources := []Source{detectSource(fp, content), functionSource{}}
+
+	sm := make(map[string]Section)
+	for _, src := range sources {
+		r, err := src.Parse(bytes.NewReader(content))
+		if err != nil {
+			log.Error(err)
+			continue
+		}
+		sm = mergeSectionMaps(sm, r)
+	}
+	return sm
+}
+
 // parseFiles parses files, returning a map of discovered Sections. Aliases in
 // identically named Sections discovered across different files are merged.
 func parseFiles(files []string) map[string]Section {
@@ -215,3 +228,22 @@ func ParseAll() map[string]Section {
 	sm := parseFiles(fs)
 	return sm
 }
+
+// scanLines runs a bufio.Scanner over r, calling onSection for every
+// "# SECTION:" marker and onLine for every other line. It is shared by the
+// line-oriented Sources (bash, zsh, fish); functionSource additionally
+// needs to track multi-line state, so it drives its own scanner.
+func scanLines(r io.Reader, onSection func(label string), onLine func(line string) error) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		l := s.Text()
+		if label, ok := matchSection(l); ok {
+			onSection(label)
+			continue
+		}
+		if err := onLine(l); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// zshSource parses zsh alias declarations, including the zsh-specific
+// -g (global) and -s (suffix) flavors:
+//
+//	alias name='cmd'
+//	alias -g name='cmd'
+//	alias -s ext='cmd'
+type zshSource struct{}
+
+var (
+	zshAliasWithComment = regexp.MustCompile(`alias (?:-(?P<flag>[gs])\s+)?(?P<name>[_a-zA-Z0-9]+)=['"](?P<command>.+)['"][^#]*#(?P<comment>.+)$`)
+	zshAlias            = regexp.MustCompile(`alias (?:-(?P<flag>[gs])\s+)?(?P<name>[_a-zA-Z0-9]+)=['"](?P<command>.+)['"]$`)
+)
+
+// zshCategory maps a zsh alias flag to the Category tag stored on Alias.
+func zshCategory(flag string) string {
+	switch flag {
+	case "g":
+		return "global"
+	case "s":
+		return "suffix"
+	default:
+		return ""
+	}
+}
+
+// parseZshAlias returns Alias parsed from string s (or the zero Alias if s
+// isn't an alias declaration).
+func parseZshAlias(s string) (Alias, error) {
+	if m := zshAliasWithComment.FindStringSubmatch(s); m != nil {
+		cat := zshCategory(m[1])
+		n := m[2]                    // name
+		c := m[3]                    // command
+		d := strings.TrimSpace(m[4]) // description/comment
+		log.Debugf("parseZshAlias: %v-%v-%v-%v", cat, n, c, d)
+		return Alias{Name: n, Cmd: c, Desc: d, Category: cat}, nil
+	}
+
+	if m := zshAlias.FindStringSubmatch(s); m != nil {
+		cat := zshCategory(m[1])
+		n := m[2] // name
+		c := m[3] // command
+		log.Debugf("parseZshAlias: %v-%v-%v", cat, n, c)
+		return Alias{Name: n, Cmd: c, Category: cat}, nil
+	}
+
+	log.Debugf("parseZshAlias: Skipping %v", s)
+	return Alias{}, nil
+}
+
+func (zshSource) Parse(r io.Reader) (map[string]Section, error) {
+	sb := newSectionBuilder()
+	err := scanLines(r, sb.setSection, func(line string) error {
+		a, err := parseZshAlias(line)
+		if err != nil {
+			return err
+		}
+		sb.add(a)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sb.result(), nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import "fmt"
+
+// bashZshWrapper and fishWrapper juggle file descriptor 3 so that the
+// command alias-panel writes to --execute-fd on Enter can be captured by
+// command substitution while fd 1/2 stay attached to the terminal for the
+// TUI itself.
+const bashZshWrapper = `alias-panel() {
+	local cmd
+	cmd="$(command alias-panel --execute-fd 3 "$@" 3>&1 1>&2)"
+	[ -n "$cmd" ] && eval "$cmd"
+}
+`
+
+const fishWrapper = `function alias-panel
+	set -l cmd (command alias-panel --execute-fd 3 $argv 3>&1 1>&2)
+	test -n "$cmd"; and eval $cmd
+end
+`
+
+// shellSnippet returns the shell integration snippet for shell (one of
+// "bash", "zsh", or "fish"), meant to be sourced into the user's rc file.
+func shellSnippet(shell string) (string, error) {
+	switch shell {
+	case "bash", "zsh":
+		return bashZshWrapper, nil
+	case "fish":
+		return fishWrapper, nil
+	default:
+		return "", fmt.Errorf("unsupported --shell %q (want bash, zsh, or fish)", shell)
+	}
+}